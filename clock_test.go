@@ -0,0 +1,41 @@
+package kubesync_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaredallard/kubesync"
+	"gotest.tools/v3/assert"
+)
+
+func TestFakeClockAfterFiresOnStep(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := kubesync.NewFakeClock(start)
+
+	ch := clock.After(time.Second)
+
+	select {
+	case <-ch:
+		t.Fatal("After should not fire before the clock advances")
+	default:
+	}
+
+	clock.Step(500 * time.Millisecond)
+	select {
+	case <-ch:
+		t.Fatal("After should not fire before its full duration has elapsed")
+	default:
+	}
+
+	clock.Step(500 * time.Millisecond)
+	select {
+	case fired := <-ch:
+		assert.Equal(t, fired, start.Add(time.Second))
+	default:
+		t.Fatal("After should have fired once the clock reached its deadline")
+	}
+
+	assert.Equal(t, clock.Now(), start.Add(time.Second))
+}
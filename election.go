@@ -0,0 +1,230 @@
+// Copyright (C) 2026 kubesync contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package kubesync
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ElectionOptions configures the behavior of an Election.
+type ElectionOptions struct {
+	// LeaseDuration is how long non-leader candidates wait after
+	// observing a refresh of the current leader's lease before
+	// attempting to become leader themselves. Defaults to 15 seconds.
+	LeaseDuration time.Duration
+
+	// RenewDeadline is how long the acting leader will keep retrying a
+	// failed lease renewal before giving up leadership. Defaults to 10
+	// seconds.
+	RenewDeadline time.Duration
+
+	// RetryPeriod is how long the leader waits between lease renewals,
+	// and how long a candidate waits after an acquisition attempt fails
+	// with an error. While the lease is held by another instance, a
+	// candidate instead waits on the lease watch with the Mutex's
+	// jittered backoff. Defaults to 2 seconds.
+	RetryPeriod time.Duration
+
+	// OnStartedLeading is called in its own goroutine once this instance
+	// becomes the leader. The provided context is canceled the moment
+	// leadership is lost, and callers should stop any work requiring
+	// leadership when it is.
+	OnStartedLeading func(ctx context.Context)
+
+	// OnStoppedLeading is called once this instance stops leading,
+	// whether because it lost the lease or Run's context was canceled.
+	OnStoppedLeading func()
+
+	// OnNewLeader is called whenever the observed holder of the lease
+	// changes, including when this instance becomes the leader. identity
+	// is the empty string if the lease is currently unheld.
+	OnNewLeader func(identity string)
+}
+
+// Election is a leader-election primitive backed by a Kubernetes Lease,
+// modeled after client-go's leaderelection package and k0s's LeasePool.
+type Election struct {
+	mutex *Mutex
+	opts  ElectionOptions
+}
+
+// NewElection creates a new Election using the provided name as the
+// underlying lease name. opts configures its timing and callbacks; zero
+// values fall back to the same defaults as client-go's leaderelection.
+func (s *Syncer) NewElection(name string, opts ElectionOptions) *Election {
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = 15 * time.Second
+	}
+	if opts.RenewDeadline == 0 {
+		opts.RenewDeadline = 10 * time.Second
+	}
+	if opts.RetryPeriod == 0 {
+		opts.RetryPeriod = 2 * time.Second
+	}
+
+	m := s.NewMutex(name)
+	m.expiration = opts.LeaseDuration
+
+	return &Election{mutex: m, opts: opts}
+}
+
+// Run participates in the election until ctx is canceled, blocking for
+// its entire duration. While running, it repeatedly attempts to become
+// the leader, invoking OnStartedLeading and OnStoppedLeading as
+// leadership is gained and lost, and OnNewLeader whenever the observed
+// holder of the lease changes, whether or not this instance is the one
+// that changed it.
+func (e *Election) Run(ctx context.Context) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		e.watchLeader(watchCtx)
+	}()
+	defer func() { <-watchDone }()
+
+	for ctx.Err() == nil {
+		if err := e.mutex.lock(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// A transient error, e.g. a failed Get/Update against the
+			// apiserver, rather than ctx being canceled. Back off and keep
+			// participating in the election instead of giving up for good.
+			if err := sleep(ctx, e.mutex.clock, e.opts.RetryPeriod); err != nil {
+				return
+			}
+			continue
+		}
+
+		e.lead(ctx)
+	}
+}
+
+// watchLeader observes the lease's holder for as long as ctx is valid,
+// invoking OnNewLeader whenever it changes, deduped against the
+// last-observed identity so a steady holder doesn't re-notify on every
+// lease update. It reuses the Mutex's lease watch, falling back to a
+// RetryPeriod poll as a safety net if a watch could not be established
+// or is closed by the API server.
+func (e *Election) watchLeader(ctx context.Context) {
+	// lastIdentity starts at "" so that finding the lease already unheld
+	// on startup isn't itself treated as a change worth notifying about.
+	lastIdentity := ""
+	check := func() {
+		holder, err := e.mutex.currentHolder(ctx)
+		if err != nil || holder == lastIdentity {
+			return
+		}
+		lastIdentity = holder
+		if e.opts.OnNewLeader != nil {
+			e.opts.OnNewLeader(holder)
+		}
+	}
+	check()
+
+	watcher := e.mutex.watchLease(ctx)
+	defer func() {
+		if watcher != nil {
+			watcher.Stop()
+		}
+	}()
+
+	for ctx.Err() == nil {
+		var events <-chan watch.Event
+		if watcher != nil {
+			events = watcher.ResultChan()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-events:
+			if !ok {
+				watcher.Stop()
+				select {
+				case <-ctx.Done():
+					return
+				case <-e.mutex.clock.After(e.opts.RetryPeriod):
+				}
+				watcher = e.mutex.watchLease(ctx)
+				continue
+			}
+			check()
+		case <-e.mutex.clock.After(e.opts.RetryPeriod):
+			// Safety net in case the watch was never established or we
+			// missed an event.
+			check()
+		}
+	}
+}
+
+// lead runs for as long as this instance holds the lease: it starts
+// OnStartedLeading, renews the lease every RetryPeriod, and gives up
+// leadership once renewal has failed for longer than RenewDeadline or
+// ctx is canceled, calling OnStoppedLeading either way.
+func (e *Election) lead(ctx context.Context) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	if e.opts.OnStartedLeading != nil {
+		go func() {
+			defer close(done)
+			e.opts.OnStartedLeading(leaderCtx)
+		}()
+	} else {
+		close(done)
+	}
+
+	lastRenew := e.mutex.clock.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			if e.opts.OnStoppedLeading != nil {
+				e.opts.OnStoppedLeading()
+			}
+			return
+		case <-e.mutex.clock.After(e.opts.RetryPeriod):
+		}
+
+		if err := e.mutex.Extend(ctx); err != nil {
+			if e.mutex.clock.Now().Sub(lastRenew) < e.opts.RenewDeadline {
+				continue
+			}
+
+			cancel()
+			<-done
+			if e.opts.OnStoppedLeading != nil {
+				e.opts.OnStoppedLeading()
+			}
+			// Best-effort: release the lease immediately instead of waiting
+			// for it to expire, so a healthy follower can take over sooner.
+			_ = e.mutex.Unlock(ctx)
+			return
+		}
+		lastRenew = e.mutex.clock.Now()
+	}
+}
@@ -0,0 +1,242 @@
+package kubesync_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jaredallard/kubesync"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestElectionBecomesLeaderAndNotifies(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	sync := kubesync.New(k, namespace, instanceID)
+
+	var startedLeading, stoppedLeading int32
+	leaders := make(chan string, 4)
+
+	election := sync.NewElection("test-election", kubesync.ElectionOptions{
+		RetryPeriod: 50 * time.Millisecond,
+		OnStartedLeading: func(ctx context.Context) {
+			startedLeading++
+			<-ctx.Done()
+		},
+		OnStoppedLeading: func() {
+			stoppedLeading++
+		},
+		OnNewLeader: func(identity string) {
+			leaders <- identity
+		},
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		election.Run(ctx)
+	}()
+
+	select {
+	case identity := <-leaders:
+		assert.Assert(t, identity != "", "expected to be notified of a non-empty leader identity")
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected OnNewLeader to be called")
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Run to return once its context was canceled")
+	}
+
+	assert.Equal(t, startedLeading, int32(1), "expected OnStartedLeading to be called exactly once")
+	assert.Equal(t, stoppedLeading, int32(1), "expected OnStoppedLeading to be called exactly once")
+}
+
+func TestElectionGivesUpLeadershipAfterRenewDeadline(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-election-renew-deadline"
+	sync := kubesync.New(k, namespace, instanceID)
+
+	started := make(chan struct{}, 1)
+	stopped := make(chan struct{}, 1)
+
+	election := sync.NewElection(lockName, kubesync.ElectionOptions{
+		RetryPeriod:   50 * time.Millisecond,
+		RenewDeadline: 150 * time.Millisecond,
+		OnStartedLeading: func(ctx context.Context) {
+			started <- struct{}{}
+			<-ctx.Done()
+		},
+		OnStoppedLeading: func() {
+			stopped <- struct{}{}
+		},
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		election.Run(ctx)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected to become leader")
+	}
+
+	// Simulate another instance stealing the lease out from under the
+	// leader (e.g. it missed a renewal window and another instance
+	// reclaimed the expired lease). Every subsequent Extend now fails
+	// with ErrLockedByAnother, so the leader should give up once
+	// RenewDeadline has passed without a successful renewal.
+	lease, err := k.CoordinationV1().Leases(namespace).Get(t.Context(), lockName, metav1.GetOptions{})
+	assert.NilError(t, err, "failed to get lease")
+	stolenBy := "someone-else"
+	lease.Spec.HolderIdentity = &stolenBy
+	_, err = k.CoordinationV1().Leases(namespace).Update(t.Context(), lease, metav1.UpdateOptions{})
+	assert.NilError(t, err, "failed to steal lease")
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected OnStoppedLeading to be called once renewal failed past RenewDeadline")
+	}
+
+	// The best-effort Unlock on the give-up path must not clobber the
+	// new holder's identity.
+	lease, err = k.CoordinationV1().Leases(namespace).Get(t.Context(), lockName, metav1.GetOptions{})
+	assert.NilError(t, err, "failed to get lease")
+	assert.Assert(t, lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == stolenBy,
+		"expected the best-effort Unlock to leave the other instance's lease alone")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Run to return once its context was canceled")
+	}
+}
+
+func TestElectionFailoverBetweenTwoInstances(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-election-failover"
+
+	sync1 := kubesync.New(k, namespace, instanceID+"-1")
+	sync2 := kubesync.New(k, namespace, instanceID+"-2")
+
+	// Either instance may win the initial race to claim the lease, so
+	// both report onto a shared "won" channel rather than assuming
+	// instance1 goes first. Each instance's own OnNewLeader notifications
+	// are kept on separate channels, since both observe every transition
+	// of the same lease independently.
+	won := make(chan int, 2)
+	leaders1 := make(chan string, 8)
+	leaders2 := make(chan string, 8)
+	opts := func(i int, leaders chan string) kubesync.ElectionOptions {
+		return kubesync.ElectionOptions{
+			RetryPeriod:   50 * time.Millisecond,
+			LeaseDuration: 2 * time.Second,
+			OnStartedLeading: func(ctx context.Context) {
+				won <- i
+				<-ctx.Done()
+			},
+			OnNewLeader: func(identity string) {
+				leaders <- identity
+			},
+		}
+	}
+	election1 := sync1.NewElection(lockName, opts(1, leaders1))
+	election2 := sync2.NewElection(lockName, opts(2, leaders2))
+
+	ctx1, cancel1 := context.WithCancel(t.Context())
+	ctx2, cancel2 := context.WithCancel(t.Context())
+	defer cancel1()
+	defer cancel2()
+
+	done1 := make(chan struct{})
+	go func() {
+		defer close(done1)
+		election1.Run(ctx1)
+	}()
+	done2 := make(chan struct{})
+	go func() {
+		defer close(done2)
+		election2.Run(ctx2)
+	}()
+
+	var firstWinner int
+	select {
+	case firstWinner = <-won:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected one instance to become leader")
+	}
+
+	// Track the loser's own view of leadership changes: its OnNewLeader
+	// calls are the ones that matter for dedup/failover, since the
+	// winner trivially observes its own transitions.
+	followerLeaders := leaders2
+	cancelWinner := cancel1
+	winnerDone := done1
+	if firstWinner == 2 {
+		followerLeaders = leaders1
+		cancelWinner = cancel2
+		winnerDone = done2
+	}
+
+	var firstLeader string
+	select {
+	case firstLeader = <-followerLeaders:
+		assert.Assert(t, firstLeader != "", "expected a non-empty leader identity")
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the follower to be notified of the initial leader")
+	}
+
+	// The loser must not become leader, nor receive a duplicate
+	// OnNewLeader notification, while the winner still holds the lease.
+	select {
+	case i := <-won:
+		t.Fatalf("instance %d should not have become leader while instance %d holds the lease", i, firstWinner)
+	case identity := <-followerLeaders:
+		t.Fatalf("unexpected duplicate OnNewLeader notification for %q", identity)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// The winner steps down without releasing the lease (mirroring a
+	// context cancellation mid-leadership); the loser should take over
+	// once the lease expires, with exactly one new, deduped OnNewLeader
+	// notification for the new holder.
+	cancelWinner()
+	select {
+	case <-winnerDone:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected instance %d's Run to return", firstWinner)
+	}
+
+	select {
+	case i := <-won:
+		assert.Assert(t, i != firstWinner, "expected the other instance to take over")
+	case <-time.After(8 * time.Second):
+		t.Fatal("expected the other instance to become leader after the first stepped down")
+	}
+
+	select {
+	case identity := <-followerLeaders:
+		assert.Assert(t, identity != "" && identity != firstLeader,
+			"expected a single deduped notification for the new leader")
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a notification that the other instance became the new leader")
+	}
+}
@@ -22,6 +22,7 @@ package kubesync
 
 import (
 	"fmt"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/kubernetes"
@@ -44,6 +45,18 @@ type Syncer struct {
 	// uniqueID should be a unique identifier for the current instance
 	// of this application.
 	uniqueID string
+
+	// leaseLocks holds a *sync.Mutex per lease name, guarding concurrent
+	// Lock/Extend/Unlock calls against the same lease within this
+	// process. The Kubernetes lease itself only provides cross-process
+	// safety; this lets a single lease name be shared safely across
+	// goroutines in one process, similar to Vault's lockPerLease.
+	leaseLocks sync.Map
+
+	// clock is used for all timekeeping and retry waits, defaulting to
+	// the real system clock. Overridable with SetClock so that tests can
+	// exercise retry and expiration logic deterministically.
+	clock Clock
 }
 
 // New creates a new Syncer instance using the provided Kubernetes
@@ -55,5 +68,20 @@ func New(k kubernetes.Interface, namespace, instanceID string) *Syncer {
 		k:         k,
 		namespace: namespace,
 		uniqueID:  fmt.Sprintf("%s-%s", instanceID, uuid.NewUUID()),
+		clock:     realClock{},
 	}
 }
+
+// SetClock overrides the Clock used by primitives created from this
+// Syncer from this point forward. Intended for tests; production
+// callers should leave the default real clock in place.
+func (s *Syncer) SetClock(c Clock) {
+	s.clock = c
+}
+
+// leaseLock returns the process-local mutex guarding operations against
+// the named lease, creating it on first use.
+func (s *Syncer) leaseLock(name string) *sync.Mutex {
+	v, _ := s.leaseLocks.LoadOrStore(name, &sync.Mutex{})
+	return v.(*sync.Mutex)
+}
@@ -2,6 +2,7 @@ package kubesync_test
 
 import (
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -58,6 +59,12 @@ func TestShouldFailUnlockWithoutLock(t *testing.T) {
 func TestShouldBeAbleToExtend(t *testing.T) {
 	k, namespace, instanceID := getMutexInputs(t)
 	sync := kubesync.New(k, namespace, instanceID)
+
+	// Use a FakeClock so the renew-time-advanced assertions below don't
+	// depend on a real sleep.
+	fakeClock := kubesync.NewFakeClock(time.Now().UTC())
+	sync.SetClock(fakeClock)
+
 	lockName := "test-lock"
 	m := sync.NewMutex(lockName)
 
@@ -68,9 +75,9 @@ func TestShouldBeAbleToExtend(t *testing.T) {
 	assert.Equal(t, beforeExtendLease.Spec.RenewTime, (*metav1.MicroTime)(nil), "lease should not have renew time set")
 	// this is nil by default, but for testing we set it to now just for
 	// easier testing.
-	beforeExtendLease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now().UTC()}
+	beforeExtendLease.Spec.RenewTime = &metav1.MicroTime{Time: fakeClock.Now()}
 
-	time.Sleep(50 * time.Millisecond)
+	fakeClock.Step(50 * time.Millisecond)
 
 	// extend the lease
 	assert.NilError(t, m.Extend(t.Context()), "failed to extend")
@@ -90,6 +97,100 @@ func TestShouldBeAbleToExtend(t *testing.T) {
 	assert.Assert(t, afterExtendLease.Spec.AcquireTime.Equal(beforeExtendLease.Spec.AcquireTime))
 }
 
+func TestHoldReportsLockStolenByAnother(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-lock"
+
+	sync := kubesync.New(k, namespace, instanceID)
+	m := sync.NewMutex(lockName)
+
+	lost, holdCtx, err := m.Hold(t.Context())
+	assert.NilError(t, err, "failed to hold lock")
+
+	// Simulate another instance stealing the lease out from under us.
+	lease, err := k.CoordinationV1().Leases(namespace).Get(t.Context(), lockName, metav1.GetOptions{})
+	assert.NilError(t, err, "failed to get lease")
+	stolenBy := "someone-else"
+	lease.Spec.HolderIdentity = &stolenBy
+	_, err = k.CoordinationV1().Leases(namespace).Update(t.Context(), lease, metav1.UpdateOptions{})
+	assert.NilError(t, err, "failed to steal lease")
+
+	select {
+	case err := <-lost:
+		assert.ErrorIs(t, err, kubesync.ErrLockedByAnother)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lost-lease channel to fire once the lease was stolen")
+	}
+
+	select {
+	case <-holdCtx.Done():
+	default:
+		t.Fatal("expected the derived context to be canceled once the lease was lost")
+	}
+}
+
+func TestConcurrentExtendCallsAreSerialized(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	syncer := kubesync.New(k, namespace, instanceID)
+	lockName := "test-lock"
+	m := syncer.NewMutex(lockName)
+
+	assert.NilError(t, m.Lock(t.Context()), "failed to lock")
+
+	// Two goroutines sharing the same *Mutex both extend concurrently.
+	// Without the per-lease process lock, one of these could race with
+	// the other's read-modify-write of the lease.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for range 2 {
+		go func() {
+			defer wg.Done()
+			assert.Check(t, m.Extend(t.Context()))
+		}()
+	}
+	wg.Wait()
+
+	assert.NilError(t, m.Unlock(t.Context()), "failed to unlock")
+}
+
+func TestLockHandoffIsFastViaWatch(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-lock"
+
+	sync1 := kubesync.New(k, namespace, instanceID)
+	m1 := sync1.NewMutex(lockName)
+	assert.NilError(t, m1.Lock(t.Context()), "failed to lock")
+
+	sync2 := kubesync.New(k, namespace, instanceID)
+	m2 := sync2.NewMutex(lockName)
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.Check(t, m2.Lock(t.Context()))
+		close(acquired)
+	}()
+
+	// Give the second lock instance a moment to start its watch before we
+	// unlock, so we're not just racing the watch's own establishment.
+	time.Sleep(50 * time.Millisecond)
+	assert.NilError(t, m1.Unlock(t.Context()), "failed to unlock")
+
+	// With watch-based wakeup this should be near-instant, well under the
+	// jittered backoff (doubling from 100ms up to 2s) that a waiter relying
+	// on polling alone would sleep between retries.
+	select {
+	case <-acquired:
+	case <-time.After(150 * time.Millisecond):
+		t.Fatal("expected watch-driven handoff to complete well under the retry backoff")
+	}
+}
+
 func TestShouldSupportDistributedLocking(t *testing.T) {
 	t.Parallel()
 
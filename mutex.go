@@ -22,15 +22,37 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand"
+	"sync"
 	"time"
 
-	coordinationv1 "k8s.io/api/coordination/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
 	coordinationclientv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
 	"k8s.io/utils/ptr"
 )
 
+// minRetryBackoff and maxRetryBackoff bound the exponential backoff
+// used while waiting to acquire an already-held lock, to avoid
+// correlated retry storms when many clients wait on the same lease.
+const (
+	minRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff = 2 * time.Second
+)
+
+// jitteredBackoff returns a randomized (full-jitter) exponential
+// backoff duration for the given zero-indexed retry attempt, doubling
+// from minRetryBackoff up to maxRetryBackoff.
+func jitteredBackoff(attempt int) time.Duration {
+	d := minRetryBackoff << attempt
+	if d <= 0 || d > maxRetryBackoff { // zero/negative means we overflowed
+		d = maxRetryBackoff
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
 // Contains various errors that can be returned by Mutex methods.
 var (
 	// ErrNotLocked is returned whenever a Mutex is attempted to be
@@ -61,16 +83,22 @@ type Mutex struct {
 	// of this application.
 	uniqueID string
 
-	// retryDur is the duration to wait between retries when attempting to
-	// lock a resource that is already locked.
-	retryDur time.Duration
-
 	// expiration is the amount of time a lock should be held for. Once
 	// this time is reached, the lock will be able to be acquired by
 	// another instance.
 	//
 	// Defaults to 10 seconds.
 	expiration time.Duration
+
+	// procLock is the process-local lock guarding this lease's
+	// Lock/Extend/Unlock calls against concurrent use from within this
+	// process. It is shared across every Mutex created from the same
+	// Syncer for the same lease name.
+	procLock *sync.Mutex
+
+	// clock is used for all timekeeping and retry waits. Inherited from
+	// the Syncer this Mutex was created from.
+	clock Clock
 }
 
 // NewMutex creates a new Mutex. Uses the provided Kubernetes lease
@@ -85,9 +113,10 @@ func (s *Syncer) NewMutex(name string) *Mutex {
 		lease:      s.k.CoordinationV1().Leases(s.namespace),
 		name:       name,
 		namespace:  s.namespace,
-		retryDur:   250 * time.Millisecond,
 		uniqueID:   s.uniqueID,
 		expiration: 10 * time.Second,
+		procLock:   s.leaseLock(name),
+		clock:      s.clock,
 	}
 }
 
@@ -105,88 +134,182 @@ func (m *Mutex) Lock(ctx context.Context) error {
 	return m.lock(ctx)
 }
 
-// lock creates a lock using a Kubernetes Lease. If the provided context
-// is canceled, ctx.Err() will be returned.
+// lock creates a lock using a Kubernetes Lease. It watches the lease so
+// that a waiter wakes as soon as the current holder calls Unlock or the
+// lease's RenewTime/HolderIdentity otherwise changes, falling back to
+// jittered exponential backoff if a watch could not be established (or
+// is closed by the API server) so an expiring lease is never missed. If
+// the provided context is canceled, ctx.Err() will be returned.
 func (m *Mutex) lock(ctx context.Context) error {
-	// Attempt to create the lease, if not found. If the lease already
-	// exists, this will no-op.
-	if _, err := m.lease.Create(ctx, &coordinationv1.Lease{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      m.name,
-			Namespace: m.namespace,
-			Labels: map[string]string{
-				"kubesync.jaredallard.github.com/lock": "true",
-			},
-		},
-		Spec: coordinationv1.LeaseSpec{},
-	}, metav1.CreateOptions{}); err != nil {
-		if !kerrors.IsAlreadyExists(err) {
-			return fmt.Errorf("failed to create lease '%s/%s': %w", m.namespace, m.name, err)
-		}
+	if err := ensureLeaseExists(ctx, m.lease, m.namespace, m.name); err != nil {
+		return err
 	}
 
+	watcher := m.watchLease(ctx)
+	defer func() {
+		if watcher != nil {
+			watcher.Stop()
+		}
+	}()
+
 	// Wait until we've acquired the lease or the context is canceled.
-	for ctx.Err() == nil {
-		lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		acquired, err := m.tryClaim(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to get lease '%s/%s': %w", m.namespace, m.name, err)
+			return err
+		}
+		if acquired {
+			break
 		}
 
-		// Handle the lease already being locked.
-		if lease.Spec.HolderIdentity != nil {
-			// Handle no duration (no expiration) or the acquire time being
-			// unknown.
-			if lease.Spec.LeaseDurationSeconds == nil || lease.Spec.AcquireTime == nil {
-				return fmt.Errorf("lock: lease has no duration or acquisition time, refusing to acquire")
+		// Lease is still held by someone else, or our update raced with
+		// another claimant. Wait to be woken by a change to the lease, with
+		// jittered exponential backoff as a safety net in case the watch
+		// was never established, was closed by the API server, or we
+		// simply missed the event that would let us reclaim an expired
+		// lease.
+		var events <-chan watch.Event
+		if watcher != nil {
+			events = watcher.ResultChan()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-events:
+			if !ok {
+				watcher.Stop()
+				// The watch was closed by the API server (e.g. an expired
+				// resourceVersion or load-shedding). Back off before
+				// re-establishing it so a server in that state isn't hammered
+				// with an immediate reconnect.
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-m.clock.After(jitteredBackoff(attempt)):
+				}
+				watcher = m.watchLease(ctx)
 			}
+		case <-m.clock.After(jitteredBackoff(attempt)):
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-			// Calculate when the lease expires.
-			leaseDur := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	return nil
+}
 
-			// use the renew time if it exists, otherwise use the acquire time.
-			lastUpdatedTime := lease.Spec.AcquireTime
-			if lease.Spec.RenewTime != nil {
-				lastUpdatedTime = lease.Spec.RenewTime
-			}
+// watchLease starts a watch on this Mutex's lease, returning nil if a
+// watch could not be established (e.g. the API server does not support
+// it), in which case the caller should fall back to polling.
+func (m *Mutex) watchLease(ctx context.Context) watch.Interface {
+	w, err := m.lease.Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", m.name).String(),
+	})
+	if err != nil {
+		return nil
+	}
+	return w
+}
 
-			if lastUpdatedTime.Add(leaseDur).After(now().Time) {
-				// Lease is still valid. Continue waiting for it to expire.
-				if err := sleep(ctx, m.retryDur); err != nil {
-					return err
-				}
-				continue
-			}
-		}
+// tryClaim makes a single attempt to claim the lease, returning true if
+// it succeeded. It holds m.procLock for the duration of the attempt so
+// that concurrent Lock/Extend/Unlock calls against the same lease
+// within this process cannot race with each other.
+func (m *Mutex) tryClaim(ctx context.Context) (bool, error) {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
+	lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease '%s/%s': %w", m.namespace, m.name, err)
+	}
 
-		var leaseTransitions int32
-		if lease.Spec.LeaseTransitions != nil {
-			leaseTransitions = *lease.Spec.LeaseTransitions
+	// Handle the lease already being locked.
+	if lease.Spec.HolderIdentity != nil {
+		// Handle no duration (no expiration) or the acquire time being
+		// unknown.
+		if lease.Spec.LeaseDurationSeconds == nil || lease.Spec.AcquireTime == nil {
+			return false, fmt.Errorf("lock: lease has no duration or acquisition time, refusing to acquire")
 		}
-		leaseTransitions++ // We're claiming the lease, so increment the transitions.
-
-		// Update the lease spec with new values to claim the lease.
-		lease.Spec.HolderIdentity = &m.uniqueID
-		lease.Spec.LeaseTransitions = &leaseTransitions
-		lease.Spec.AcquireTime = now()
-		lease.Spec.LeaseDurationSeconds = ptr.To[int32](int32(math.Round(m.expiration.Seconds())))
-
-		// Submit the update to the lease on the API server.
-		_, err = m.lease.Update(ctx, lease, metav1.UpdateOptions{})
-		if err == nil {
-			// Acquired the lease, leave the loop.
-			break
+
+		// Calculate when the lease expires.
+		leaseDur := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+
+		// use the renew time if it exists, otherwise use the acquire time.
+		lastUpdatedTime := lease.Spec.AcquireTime
+		if lease.Spec.RenewTime != nil {
+			lastUpdatedTime = lease.Spec.RenewTime
 		}
 
-		// Failed to update the lease, try again later.
-		if err := sleep(ctx, m.retryDur); err != nil {
-			return err
+		if lastUpdatedTime.Add(leaseDur).After(now(m.clock).Time) {
+			// Lease is still valid. Caller should keep waiting for it to
+			// expire.
+			return false, nil
 		}
 	}
-	if ctx.Err() != nil {
-		return ctx.Err()
+
+	var leaseTransitions int32
+	if lease.Spec.LeaseTransitions != nil {
+		leaseTransitions = *lease.Spec.LeaseTransitions
 	}
+	leaseTransitions++ // We're claiming the lease, so increment the transitions.
 
-	return nil
+	// Update the lease spec with new values to claim the lease.
+	lease.Spec.HolderIdentity = &m.uniqueID
+	lease.Spec.LeaseTransitions = &leaseTransitions
+	lease.Spec.AcquireTime = now(m.clock)
+	lease.Spec.LeaseDurationSeconds = ptr.To[int32](int32(math.Round(m.expiration.Seconds())))
+
+	// Submit the update to the lease on the API server.
+	if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		// Failed to update the lease, likely raced with another claimant.
+		// Caller should try again later.
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Hold acquires the lock and then spawns a background goroutine that
+// automatically renews the lease at a fraction of its expiration (every
+// expiration/3, mirroring kubelet's node-lease renewer), removing the
+// burden of manually calling Extend in a timer loop.
+//
+// It returns a channel that receives the single error explaining why
+// the lock was lost (an API error, the lock being stolen by another
+// holder, or the provided context being canceled), and a context
+// derived from ctx that is canceled the moment that happens. Callers
+// should tie the lifetime of any work that requires the lock to the
+// returned context.
+func (m *Mutex) Hold(ctx context.Context) (<-chan error, context.Context, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	lost := make(chan error, 1)
+	holdCtx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer cancel()
+
+		renewEvery := m.expiration / 3
+
+		for {
+			select {
+			case <-ctx.Done():
+				lost <- ctx.Err()
+				return
+			case <-m.clock.After(renewEvery):
+				if err := m.Extend(ctx); err != nil {
+					lost <- err
+					return
+				}
+			}
+		}
+	}()
+
+	return lost, holdCtx, nil
 }
 
 // Extend extends the lock. Lock must be called at least once before
@@ -194,6 +317,9 @@ func (m *Mutex) lock(ctx context.Context) error {
 // to be extended for any reason, in this case the caller must stop
 // doing work that required the lock.
 func (m *Mutex) Extend(ctx context.Context) error {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
 	lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
 	if err != nil {
 		return ErrNotLocked
@@ -210,7 +336,7 @@ func (m *Mutex) Extend(ctx context.Context) error {
 	}
 
 	// Set the renew time to now.
-	lease.Spec.RenewTime = now()
+	lease.Spec.RenewTime = now(m.clock)
 
 	// Submit the update to the lease on the API server.
 	if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
@@ -222,6 +348,9 @@ func (m *Mutex) Extend(ctx context.Context) error {
 // Unlock forcibly unlocks the lock for the provided resource name. An
 // error is returned if the lock was unable to be unlocked.
 func (m *Mutex) Unlock(ctx context.Context) error {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
 	lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
 	if err != nil {
 		return ErrNotLocked
@@ -249,3 +378,21 @@ func (m *Mutex) Unlock(ctx context.Context) error {
 	}
 	return nil
 }
+
+// currentHolder returns the identity of the current holder of the
+// lease, or the empty string if the lease is unheld or does not exist
+// yet.
+func (m *Mutex) currentHolder(ctx context.Context) (string, error) {
+	lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get lease '%s/%s': %w", m.namespace, m.name, err)
+	}
+
+	if lease.Spec.HolderIdentity == nil {
+		return "", nil
+	}
+	return *lease.Spec.HolderIdentity, nil
+}
@@ -0,0 +1,519 @@
+// Copyright (C) 2026 kubesync contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package kubesync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclientv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/utils/ptr"
+)
+
+// readersAnnotation is the annotation key RWMutex uses to store its
+// reader set on the backing lease, since a Lease's HolderIdentity is a
+// single scalar and cannot represent multiple concurrent readers.
+const readersAnnotation = "kubesync.jaredallard.github.com/readers"
+
+// pendingWriterAnnotation is the annotation key RWMutex uses to record
+// that a writer is waiting on current readers to finish, so tryRLock
+// stops admitting new ones in the meantime.
+const pendingWriterAnnotation = "kubesync.jaredallard.github.com/pending-writer"
+
+// rwMutexReader is a single entry in an RWMutex's reader set.
+type rwMutexReader struct {
+	Identity   string    `json:"identity"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// rwMutexPendingWriter records a writer waiting for current readers to
+// release, stored on the lease's pendingWriterAnnotation.
+type rwMutexPendingWriter struct {
+	Identity   string    `json:"identity"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// RWMutex is a distributed reader/writer mutex using a single
+// Kubernetes Lease as the backing store, matching the semantics of the
+// standard library's sync.RWMutex. A writer holds the lease the same
+// way Mutex does; readers are instead tracked in a JSON-encoded
+// annotation, expired lazily using the same expiration semantics as
+// Mutex, and mutated with optimistic-concurrency retries on conflicts.
+type RWMutex struct {
+	// lease is the Kubernetes lease client.
+	lease coordinationclientv1.LeaseInterface
+
+	// name is the name of the RWMutex. Used as a primary key for the
+	// lease.
+	name string
+
+	// namespace is the namespace to create leases in. Must match the
+	// namespace used to create the lease client.
+	namespace string
+
+	// uniqueID should be a unique identifier for the current instance
+	// of this application.
+	uniqueID string
+
+	// procLock is the process-local lock guarding this lease's
+	// RLock/RUnlock/Lock/Unlock calls against concurrent use from within
+	// this process.
+	procLock *sync.Mutex
+
+	// expiration is the amount of time a read or write lock should be
+	// held for. Once this time is reached, the lock will be able to be
+	// acquired by another instance.
+	//
+	// Defaults to 10 seconds.
+	expiration time.Duration
+
+	// clock is used for all timekeeping and retry waits. Inherited from
+	// the Syncer this RWMutex was created from.
+	clock Clock
+}
+
+// NewRWMutex creates a new RWMutex backed by a lease with the given
+// name.
+func (s *Syncer) NewRWMutex(name string) *RWMutex {
+	return &RWMutex{
+		lease:      s.k.CoordinationV1().Leases(s.namespace),
+		name:       name,
+		namespace:  s.namespace,
+		uniqueID:   s.uniqueID,
+		procLock:   s.leaseLock(name),
+		expiration: 10 * time.Second,
+		clock:      s.clock,
+	}
+}
+
+// RLock acquires a read lock. Multiple readers may hold the lock
+// simultaneously, but RLock blocks until any current or waiting writer
+// has released it. If the provided context is canceled, ctx.Err() will
+// be returned.
+func (m *RWMutex) RLock(ctx context.Context) error {
+	if err := ensureLeaseExists(ctx, m.lease, m.namespace, m.name); err != nil {
+		return err
+	}
+
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		acquired, err := m.tryRLock(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if err := sleep(ctx, m.clock, jitteredBackoff(attempt)); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// RUnlock releases a read lock previously acquired with RLock. An error
+// is returned if this instance does not currently hold a read lock.
+func (m *RWMutex) RUnlock(ctx context.Context) error {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
+	for {
+		lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+		if err != nil {
+			return ErrNotLocked
+		}
+
+		readers, err := readReaders(lease)
+		if err != nil {
+			return err
+		}
+		readers = liveReaders(readers, now(m.clock).Time)
+
+		found := false
+		remaining := make([]rwMutexReader, 0, len(readers))
+		for _, r := range readers {
+			if !found && r.Identity == m.uniqueID {
+				found = true
+				continue
+			}
+			remaining = append(remaining, r)
+		}
+		if !found {
+			return ErrNotLocked
+		}
+
+		if err := writeReaders(lease, remaining); err != nil {
+			return err
+		}
+		if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+			if kerrors.IsConflict(err) {
+				// Raced with another reader or writer; retry against the
+				// latest lease.
+				continue
+			}
+			return fmt.Errorf("runlock: error when trying to update Lease: %w", err)
+		}
+		return nil
+	}
+}
+
+// Lock acquires the write lock. It blocks until no writer holds the
+// lock and no readers are active. While waiting, it marks itself as a
+// pending writer so that new RLock callers stop being admitted,
+// matching sync.RWMutex's writer-preference semantics. If the provided
+// context is canceled, ctx.Err() will be returned.
+func (m *RWMutex) Lock(ctx context.Context) error {
+	if err := ensureLeaseExists(ctx, m.lease, m.namespace, m.name); err != nil {
+		return err
+	}
+
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		acquired, err := m.tryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if err := sleep(ctx, m.clock, jitteredBackoff(attempt)); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// Unlock releases the write lock. An error is returned if this instance
+// does not currently hold it.
+func (m *RWMutex) Unlock(ctx context.Context) error {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
+	for {
+		lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+		if err != nil {
+			return ErrNotLocked
+		}
+
+		if lease.Spec.HolderIdentity == nil {
+			return ErrNotLocked
+		}
+		if *lease.Spec.HolderIdentity != m.uniqueID {
+			return ErrLockedByAnother
+		}
+
+		lease.Spec.HolderIdentity = nil
+		lease.Spec.AcquireTime = nil
+		lease.Spec.RenewTime = nil
+		lease.Spec.LeaseDurationSeconds = nil
+
+		if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+			if kerrors.IsConflict(err) {
+				// Raced with a reader's RLock/RUnlock touching the same
+				// lease; retry against the latest lease.
+				continue
+			}
+			return fmt.Errorf("unlock: error when trying to update Lease: %w", err)
+		}
+		return nil
+	}
+}
+
+// Extend refreshes the lock held by this instance, whether a read lock
+// acquired via RLock or the write lock acquired via Lock. Callers
+// holding a lock for longer than expiration must call this
+// periodically (e.g. via a Hold-style timer loop, as Mutex.Hold does)
+// to avoid being barged by another reader or writer. ErrLockedByAnother
+// is returned if another instance holds the write lock; otherwise
+// ErrNotLocked is returned if this instance does not currently hold
+// either kind of lock.
+func (m *RWMutex) Extend(ctx context.Context) error {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
+	for {
+		lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+		if err != nil {
+			return ErrNotLocked
+		}
+
+		if lease.Spec.HolderIdentity != nil {
+			if *lease.Spec.HolderIdentity != m.uniqueID {
+				return ErrLockedByAnother
+			}
+			lease.Spec.RenewTime = now(m.clock)
+		} else {
+			readers, err := readReaders(lease)
+			if err != nil {
+				return err
+			}
+
+			found := false
+			for i := range readers {
+				if readers[i].Identity == m.uniqueID {
+					readers[i].Expiration = now(m.clock).Time.Add(m.expiration)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return ErrNotLocked
+			}
+			if err := writeReaders(lease, readers); err != nil {
+				return err
+			}
+		}
+
+		if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+			if kerrors.IsConflict(err) {
+				// Raced with another reader or writer; retry against the
+				// latest lease.
+				continue
+			}
+			return fmt.Errorf("extend: error when trying to update Lease: %w", err)
+		}
+		return nil
+	}
+}
+
+// tryRLock makes a single attempt to add this instance to the reader
+// set, returning true if it succeeded.
+func (m *RWMutex) tryRLock(ctx context.Context) (bool, error) {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
+	lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease '%s/%s': %w", m.namespace, m.name, err)
+	}
+
+	// A writer holds the lock; readers must wait for it to release.
+	if lease.Spec.HolderIdentity != nil {
+		if !m.writerExpired(lease) {
+			return false, nil
+		}
+		// The previous writer's lease expired without it releasing (e.g. it
+		// crashed). Clear its stale fields now, rather than leaving the
+		// lease simultaneously advertising a HolderIdentity and a live
+		// reader set for other callers to special-case.
+		lease.Spec.HolderIdentity = nil
+		lease.Spec.AcquireTime = nil
+		lease.Spec.RenewTime = nil
+		lease.Spec.LeaseDurationSeconds = nil
+	}
+
+	pendingWriter, err := readPendingWriter(lease)
+	if err != nil {
+		return false, err
+	}
+	if pendingWriter != nil && pendingWriter.Expiration.After(now(m.clock).Time) {
+		// A writer is waiting on current readers to finish; stop admitting
+		// new readers so it isn't starved, matching sync.RWMutex's
+		// writer-preference semantics.
+		return false, nil
+	}
+
+	readers, err := readReaders(lease)
+	if err != nil {
+		return false, err
+	}
+	readers = liveReaders(readers, now(m.clock).Time)
+	readers = append(readers, rwMutexReader{
+		Identity:   m.uniqueID,
+		Expiration: now(m.clock).Time.Add(m.expiration),
+	})
+
+	if err := writeReaders(lease, readers); err != nil {
+		return false, err
+	}
+
+	if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if kerrors.IsConflict(err) {
+			// Raced with another reader or writer; try again later.
+			return false, nil
+		}
+		return false, fmt.Errorf("rlock: error when trying to update Lease: %w", err)
+	}
+	return true, nil
+}
+
+// tryLock makes a single attempt to claim the write lock, returning
+// true if it succeeded.
+func (m *RWMutex) tryLock(ctx context.Context) (bool, error) {
+	m.procLock.Lock()
+	defer m.procLock.Unlock()
+
+	lease, err := m.lease.Get(ctx, m.name, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease '%s/%s': %w", m.namespace, m.name, err)
+	}
+
+	if lease.Spec.HolderIdentity != nil && !m.writerExpired(lease) {
+		return false, nil
+	}
+
+	readers, err := readReaders(lease)
+	if err != nil {
+		return false, err
+	}
+	if len(liveReaders(readers, now(m.clock).Time)) > 0 {
+		// Readers are still active. Mark ourselves as a pending writer so
+		// tryRLock stops admitting new ones while we wait, then try again
+		// later.
+		return false, m.markPendingWriter(ctx, lease)
+	}
+
+	lease.Spec.HolderIdentity = &m.uniqueID
+	lease.Spec.AcquireTime = now(m.clock)
+	// Reset RenewTime so a previous holder's stale value (left behind if
+	// it crashed after calling Extend) isn't inherited by this fresh
+	// claim; writerExpired prefers RenewTime over AcquireTime when set,
+	// so a stale one would make the lock we just acquired look expired
+	// to every other caller.
+	lease.Spec.RenewTime = nil
+	lease.Spec.LeaseDurationSeconds = ptr.To[int32](int32(math.Round(m.expiration.Seconds())))
+	if err := writeReaders(lease, nil); err != nil {
+		return false, err
+	}
+	writePendingWriter(lease, nil)
+
+	if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if kerrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("lock: error when trying to update Lease: %w", err)
+	}
+	return true, nil
+}
+
+// markPendingWriter records this instance as waiting for the write
+// lock and submits the update, so tryRLock stops admitting new readers
+// until it expires or a writer claims the lock. Without this, a
+// continuous stream of readers could starve a waiting writer
+// indefinitely. The marker expires after m.expiration so a writer that
+// gives up or crashes while waiting cannot starve readers forever.
+func (m *RWMutex) markPendingWriter(ctx context.Context, lease *coordinationv1.Lease) error {
+	writePendingWriter(lease, &rwMutexPendingWriter{
+		Identity:   m.uniqueID,
+		Expiration: now(m.clock).Time.Add(m.expiration),
+	})
+	if _, err := m.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if kerrors.IsConflict(err) {
+			// Raced with another reader or writer; we'll mark again on the
+			// next poll.
+			return nil
+		}
+		return fmt.Errorf("lock: error when trying to mark pending writer: %w", err)
+	}
+	return nil
+}
+
+// writerExpired reports whether the lease's current writer's lock has
+// expired, using the same expiration semantics as Mutex.
+func (m *RWMutex) writerExpired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.LeaseDurationSeconds == nil || lease.Spec.AcquireTime == nil {
+		return false
+	}
+
+	leaseDur := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	lastUpdated := lease.Spec.AcquireTime
+	if lease.Spec.RenewTime != nil {
+		lastUpdated = lease.Spec.RenewTime
+	}
+	return !lastUpdated.Add(leaseDur).After(now(m.clock).Time)
+}
+
+// readReaders decodes the reader set stored on the lease's
+// readersAnnotation, returning nil if it is unset.
+func readReaders(lease *coordinationv1.Lease) ([]rwMutexReader, error) {
+	raw, ok := lease.Annotations[readersAnnotation]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	var readers []rwMutexReader
+	if err := json.Unmarshal([]byte(raw), &readers); err != nil {
+		return nil, fmt.Errorf("failed to decode readers annotation: %w", err)
+	}
+	return readers, nil
+}
+
+// writeReaders encodes readers and stores them on the lease's
+// readersAnnotation.
+func writeReaders(lease *coordinationv1.Lease, readers []rwMutexReader) error {
+	raw, err := json.Marshal(readers)
+	if err != nil {
+		return fmt.Errorf("failed to encode readers annotation: %w", err)
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[readersAnnotation] = string(raw)
+	return nil
+}
+
+// readPendingWriter decodes the pending writer stored on the lease's
+// pendingWriterAnnotation, returning nil if it is unset.
+func readPendingWriter(lease *coordinationv1.Lease) (*rwMutexPendingWriter, error) {
+	raw, ok := lease.Annotations[pendingWriterAnnotation]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	var w rwMutexPendingWriter
+	if err := json.Unmarshal([]byte(raw), &w); err != nil {
+		return nil, fmt.Errorf("failed to decode pending writer annotation: %w", err)
+	}
+	return &w, nil
+}
+
+// writePendingWriter stores w on the lease's pendingWriterAnnotation,
+// clearing it if w is nil.
+func writePendingWriter(lease *coordinationv1.Lease, w *rwMutexPendingWriter) {
+	if w == nil {
+		delete(lease.Annotations, pendingWriterAnnotation)
+		return
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	raw, _ := json.Marshal(w) // a fixed struct of string/time.Time cannot fail to encode.
+	lease.Annotations[pendingWriterAnnotation] = string(raw)
+}
+
+// liveReaders prunes stale entries whose expiration has passed,
+// implementing background expiry of readers lazily. RUnlock and
+// tryRLock drop stale entries when they rewrite the reader set, and
+// tryLock ignores them; Extend reads the set without pruning it.
+func liveReaders(readers []rwMutexReader, now time.Time) []rwMutexReader {
+	live := make([]rwMutexReader, 0, len(readers))
+	for _, r := range readers {
+		if r.Expiration.After(now) {
+			live = append(live, r)
+		}
+	}
+	return live
+}
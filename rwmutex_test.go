@@ -0,0 +1,209 @@
+package kubesync_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaredallard/kubesync"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRWMutexAllowsConcurrentReaders(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-rwlock"
+
+	sync1 := kubesync.New(k, namespace, instanceID+"-1")
+	sync2 := kubesync.New(k, namespace, instanceID+"-2")
+	rw1 := sync1.NewRWMutex(lockName)
+	rw2 := sync2.NewRWMutex(lockName)
+
+	assert.NilError(t, rw1.RLock(t.Context()), "failed to rlock first reader")
+	assert.NilError(t, rw2.RLock(t.Context()), "failed to rlock second reader")
+
+	assert.NilError(t, rw1.RUnlock(t.Context()), "failed to runlock first reader")
+	assert.NilError(t, rw2.RUnlock(t.Context()), "failed to runlock second reader")
+}
+
+func TestRWMutexWriterBlocksReaders(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-rwlock"
+
+	writerSync := kubesync.New(k, namespace, instanceID+"-writer")
+	readerSync := kubesync.New(k, namespace, instanceID+"-reader")
+	writer := writerSync.NewRWMutex(lockName)
+	reader := readerSync.NewRWMutex(lockName)
+
+	assert.NilError(t, writer.Lock(t.Context()), "failed to lock writer")
+
+	readerLocked := make(chan struct{})
+	go func() {
+		assert.Check(t, reader.RLock(t.Context()))
+		close(readerLocked)
+	}()
+
+	select {
+	case <-readerLocked:
+		t.Fatal("reader should not have been able to lock while writer holds the lock")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	assert.NilError(t, writer.Unlock(t.Context()), "failed to unlock writer")
+
+	select {
+	case <-readerLocked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("reader should have acquired the lock after the writer released it")
+	}
+}
+
+func TestRWMutexWriterPreferenceBlocksNewReaders(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-rwlock"
+
+	reader1Sync := kubesync.New(k, namespace, instanceID+"-reader1")
+	writerSync := kubesync.New(k, namespace, instanceID+"-writer")
+	reader2Sync := kubesync.New(k, namespace, instanceID+"-reader2")
+	reader1 := reader1Sync.NewRWMutex(lockName)
+	writer := writerSync.NewRWMutex(lockName)
+	reader2 := reader2Sync.NewRWMutex(lockName)
+
+	assert.NilError(t, reader1.RLock(t.Context()), "failed to rlock first reader")
+
+	writerLocked := make(chan struct{})
+	go func() {
+		assert.Check(t, writer.Lock(t.Context()))
+		close(writerLocked)
+	}()
+
+	// Give the writer a chance to mark itself pending while reader1 is
+	// still active.
+	select {
+	case <-writerLocked:
+		t.Fatal("writer should not have acquired the lock while a reader is active")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	reader2Locked := make(chan struct{})
+	go func() {
+		assert.Check(t, reader2.RLock(t.Context()))
+		close(reader2Locked)
+	}()
+
+	// With a writer pending, a new reader must not be admitted even
+	// though no writer currently holds the lock.
+	select {
+	case <-reader2Locked:
+		t.Fatal("second reader should not have been admitted while a writer is pending")
+	case <-writerLocked:
+		t.Fatal("writer should not have acquired the lock while a reader is active")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	assert.NilError(t, reader1.RUnlock(t.Context()), "failed to runlock first reader")
+
+	select {
+	case <-writerLocked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("writer should have acquired the lock once the reader released it")
+	}
+
+	// The second reader must still be blocked by the now-held write lock.
+	select {
+	case <-reader2Locked:
+		t.Fatal("second reader should not have been admitted while the writer holds the lock")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	assert.NilError(t, writer.Unlock(t.Context()), "failed to unlock writer")
+
+	select {
+	case <-reader2Locked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second reader should have acquired the lock after the writer released it")
+	}
+}
+
+func TestRWMutexRLockClearsExpiredWriter(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	lockName := "test-rwlock"
+
+	fakeClock := kubesync.NewFakeClock(time.Now().UTC())
+
+	writerSync := kubesync.New(k, namespace, instanceID+"-writer")
+	writerSync.SetClock(fakeClock)
+	readerSync := kubesync.New(k, namespace, instanceID+"-reader")
+	readerSync.SetClock(fakeClock)
+
+	writer := writerSync.NewRWMutex(lockName)
+	reader := readerSync.NewRWMutex(lockName)
+
+	assert.NilError(t, writer.Lock(t.Context()), "failed to lock writer")
+
+	// Step past expiration without the writer ever renewing, simulating
+	// a crash.
+	fakeClock.Step(11 * time.Second)
+
+	assert.NilError(t, reader.RLock(t.Context()), "failed to rlock after writer expired")
+
+	lease, err := k.CoordinationV1().Leases(namespace).Get(t.Context(), lockName, metav1.GetOptions{})
+	assert.NilError(t, err, "failed to get lease")
+	assert.Equal(t, lease.Spec.HolderIdentity, (*string)(nil), "expired writer's HolderIdentity should have been cleared")
+	assert.Equal(t, lease.Spec.AcquireTime, (*metav1.MicroTime)(nil), "expired writer's AcquireTime should have been cleared")
+	assert.Equal(t, lease.Spec.RenewTime, (*metav1.MicroTime)(nil), "expired writer's RenewTime should have been cleared")
+	assert.Equal(t, lease.Spec.LeaseDurationSeconds, (*int32)(nil), "expired writer's LeaseDurationSeconds should have been cleared")
+
+	assert.NilError(t, reader.RUnlock(t.Context()), "failed to runlock")
+}
+
+func TestRWMutexRUnlockWithoutRLockFails(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	sync := kubesync.New(k, namespace, instanceID)
+	rw := sync.NewRWMutex("test-rwlock")
+
+	assert.ErrorIs(t, rw.RUnlock(t.Context()), kubesync.ErrNotLocked)
+}
+
+func TestRWMutexExtendReader(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	sync := kubesync.New(k, namespace, instanceID)
+	rw := sync.NewRWMutex("test-rwlock")
+
+	assert.NilError(t, rw.RLock(t.Context()), "failed to rlock")
+	assert.NilError(t, rw.Extend(t.Context()), "failed to extend read lock")
+	assert.NilError(t, rw.RUnlock(t.Context()), "failed to runlock")
+}
+
+func TestRWMutexExtendWriter(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	sync := kubesync.New(k, namespace, instanceID)
+	rw := sync.NewRWMutex("test-rwlock")
+
+	assert.NilError(t, rw.Lock(t.Context()), "failed to lock")
+	assert.NilError(t, rw.Extend(t.Context()), "failed to extend write lock")
+	assert.NilError(t, rw.Unlock(t.Context()), "failed to unlock")
+}
+
+func TestRWMutexExtendWithoutLockFails(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	sync := kubesync.New(k, namespace, instanceID)
+	rw := sync.NewRWMutex("test-rwlock")
+
+	assert.ErrorIs(t, rw.Extend(t.Context()), kubesync.ErrNotLocked)
+}
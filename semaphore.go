@@ -0,0 +1,323 @@
+// Copyright (C) 2026 kubesync contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package kubesync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclientv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+)
+
+// semaphoreSlotsAnnotation is the annotation key Semaphore uses to
+// store its slot table on the backing lease.
+const semaphoreSlotsAnnotation = "kubesync.jaredallard.github.com/slots"
+
+// semaphoreSlot is a single entry in a Semaphore's slot table.
+type semaphoreSlot struct {
+	Index      int       `json:"index"`
+	Identity   string    `json:"identity"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	RenewedAt  time.Time `json:"renewedAt"`
+}
+
+// Semaphore is a distributed counting lock, backed by a single
+// Kubernetes Lease, that permits up to N concurrent holders. Since a
+// Lease's HolderIdentity is a single scalar, holders are instead
+// tracked as a slot table stored as a JSON annotation, mutated with
+// optimistic-concurrency retries on conflicts.
+type Semaphore struct {
+	// lease is the Kubernetes lease client.
+	lease coordinationclientv1.LeaseInterface
+
+	// name is the name of the Semaphore. Used as a primary key for the
+	// lease.
+	name string
+
+	// namespace is the namespace to create leases in. Must match the
+	// namespace used to create the lease client.
+	namespace string
+
+	// uniqueID should be a unique identifier for the current instance
+	// of this application.
+	uniqueID string
+
+	// n is the number of concurrent holders permitted.
+	n int
+
+	// procLock is the process-local lock guarding this lease's
+	// Acquire/Release/Extend calls against concurrent use from within
+	// this process.
+	procLock *sync.Mutex
+
+	// expiration is the amount of time a slot should be held for. Once
+	// this time is reached, the slot will be able to be acquired by
+	// another instance.
+	//
+	// Defaults to 10 seconds.
+	expiration time.Duration
+
+	// clock is used for all timekeeping and retry waits. Inherited from
+	// the Syncer this Semaphore was created from.
+	clock Clock
+}
+
+// NewSemaphore creates a new Semaphore backed by a lease with the given
+// name, permitting up to n concurrent holders.
+func (s *Syncer) NewSemaphore(name string, n int) *Semaphore {
+	return &Semaphore{
+		lease:      s.k.CoordinationV1().Leases(s.namespace),
+		name:       name,
+		namespace:  s.namespace,
+		uniqueID:   s.uniqueID,
+		n:          n,
+		procLock:   s.leaseLock(name),
+		expiration: 10 * time.Second,
+		clock:      s.clock,
+	}
+}
+
+// Acquire acquires one of the Semaphore's N slots, blocking until one
+// is free or the provided context is canceled, in which case ctx.Err()
+// is returned.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if err := ensureLeaseExists(ctx, s.lease, s.namespace, s.name); err != nil {
+		return err
+	}
+
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		acquired, err := s.tryAcquire(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if err := sleep(ctx, s.clock, jitteredBackoff(attempt)); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// Release releases the slot held by this instance. ErrNotLocked is
+// returned if this instance does not currently hold one. Unlike Mutex,
+// ErrLockedByAnother is never returned: a semaphore has no single
+// holder that can claim "locked by another", just slots that are
+// either this instance's or not.
+func (s *Semaphore) Release(ctx context.Context) error {
+	s.procLock.Lock()
+	defer s.procLock.Unlock()
+
+	for {
+		lease, err := s.lease.Get(ctx, s.name, metav1.GetOptions{})
+		if err != nil {
+			return ErrNotLocked
+		}
+
+		slots, err := readSlots(lease)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		remaining := make([]semaphoreSlot, 0, len(slots))
+		for _, slot := range slots {
+			if slot.Identity == s.uniqueID {
+				found = true
+				continue
+			}
+			remaining = append(remaining, slot)
+		}
+		if !found {
+			return ErrNotLocked
+		}
+
+		if err := writeSlots(lease, remaining); err != nil {
+			return err
+		}
+		if _, err := s.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+			if kerrors.IsConflict(err) {
+				// Raced with another claimant; retry against the latest lease.
+				continue
+			}
+			return fmt.Errorf("release: error when trying to update Lease: %w", err)
+		}
+		return nil
+	}
+}
+
+// Extend refreshes the slot held by this instance. ErrNotLocked is
+// returned if this instance does not currently hold one; as with
+// Release, ErrLockedByAnother is never returned since a semaphore has
+// no single holder to be locked by another.
+func (s *Semaphore) Extend(ctx context.Context) error {
+	s.procLock.Lock()
+	defer s.procLock.Unlock()
+
+	for {
+		lease, err := s.lease.Get(ctx, s.name, metav1.GetOptions{})
+		if err != nil {
+			return ErrNotLocked
+		}
+
+		slots, err := readSlots(lease)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for i := range slots {
+			if slots[i].Identity == s.uniqueID {
+				slots[i].RenewedAt = now(s.clock).Time
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrNotLocked
+		}
+
+		if err := writeSlots(lease, slots); err != nil {
+			return err
+		}
+		if _, err := s.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+			if kerrors.IsConflict(err) {
+				// Raced with another claimant; retry against the latest lease.
+				continue
+			}
+			return fmt.Errorf("extend: error when trying to update Lease: %w", err)
+		}
+		return nil
+	}
+}
+
+// tryAcquire makes a single attempt to claim a free or expired slot,
+// returning true if it succeeded (including if this instance already
+// holds one).
+func (s *Semaphore) tryAcquire(ctx context.Context) (bool, error) {
+	s.procLock.Lock()
+	defer s.procLock.Unlock()
+
+	lease, err := s.lease.Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease '%s/%s': %w", s.namespace, s.name, err)
+	}
+
+	slots, err := readSlots(lease)
+	if err != nil {
+		return false, err
+	}
+
+	held := make(map[int]semaphoreSlot, len(slots))
+	for _, slot := range slots {
+		if s.slotExpired(slot) {
+			continue
+		}
+		if slot.Identity == s.uniqueID {
+			// Already hold a slot.
+			return true, nil
+		}
+		held[slot.Index] = slot
+	}
+
+	freeIndex := -1
+	for i := 0; i < s.n; i++ {
+		if _, ok := held[i]; !ok {
+			freeIndex = i
+			break
+		}
+	}
+	if freeIndex == -1 {
+		// All slots are taken.
+		return false, nil
+	}
+
+	nowTime := now(s.clock).Time
+	held[freeIndex] = semaphoreSlot{
+		Index:      freeIndex,
+		Identity:   s.uniqueID,
+		AcquiredAt: nowTime,
+		RenewedAt:  nowTime,
+	}
+
+	newSlots := make([]semaphoreSlot, 0, len(held))
+	for _, slot := range held {
+		newSlots = append(newSlots, slot)
+	}
+
+	if err := writeSlots(lease, newSlots); err != nil {
+		return false, err
+	}
+
+	if _, err := s.lease.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if kerrors.IsConflict(err) {
+			// Raced with another claimant; try again later.
+			return false, nil
+		}
+		return false, fmt.Errorf("acquire: error when trying to update Lease: %w", err)
+	}
+	return true, nil
+}
+
+// slotExpired reports whether slot's holder has failed to renew it
+// within its expiration, using the same expiration semantics as Mutex.
+func (s *Semaphore) slotExpired(slot semaphoreSlot) bool {
+	lastUpdated := slot.AcquiredAt
+	if !slot.RenewedAt.IsZero() {
+		lastUpdated = slot.RenewedAt
+	}
+	return !lastUpdated.Add(s.expiration).After(now(s.clock).Time)
+}
+
+// readSlots decodes the slot table stored on the lease's
+// semaphoreSlotsAnnotation, returning nil if it is unset.
+func readSlots(lease *coordinationv1.Lease) ([]semaphoreSlot, error) {
+	raw, ok := lease.Annotations[semaphoreSlotsAnnotation]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	var slots []semaphoreSlot
+	if err := json.Unmarshal([]byte(raw), &slots); err != nil {
+		return nil, fmt.Errorf("failed to decode slots annotation: %w", err)
+	}
+	return slots, nil
+}
+
+// writeSlots encodes slots and stores them on the lease's
+// semaphoreSlotsAnnotation.
+func writeSlots(lease *coordinationv1.Lease, slots []semaphoreSlot) error {
+	raw, err := json.Marshal(slots)
+	if err != nil {
+		return fmt.Errorf("failed to encode slots annotation: %w", err)
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[semaphoreSlotsAnnotation] = string(raw)
+	return nil
+}
@@ -0,0 +1,95 @@
+package kubesync_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaredallard/kubesync"
+	"gotest.tools/v3/assert"
+)
+
+func TestSemaphoreLimitsConcurrentHolders(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	semName := "test-semaphore"
+
+	sync1 := kubesync.New(k, namespace, instanceID+"-1")
+	sync2 := kubesync.New(k, namespace, instanceID+"-2")
+	sync3 := kubesync.New(k, namespace, instanceID+"-3")
+
+	sem1 := sync1.NewSemaphore(semName, 2)
+	sem2 := sync2.NewSemaphore(semName, 2)
+	sem3 := sync3.NewSemaphore(semName, 2)
+
+	assert.NilError(t, sem1.Acquire(t.Context()), "failed to acquire first slot")
+	assert.NilError(t, sem2.Acquire(t.Context()), "failed to acquire second slot")
+
+	thirdAcquired := make(chan struct{})
+	go func() {
+		assert.Check(t, sem3.Acquire(t.Context()))
+		close(thirdAcquired)
+	}()
+
+	select {
+	case <-thirdAcquired:
+		t.Fatal("third instance should not have acquired a slot while both are held")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	assert.NilError(t, sem1.Release(t.Context()), "failed to release first slot")
+
+	select {
+	case <-thirdAcquired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("third instance should have acquired the freed slot")
+	}
+}
+
+func TestSemaphoreReclaimsExpiredSlot(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	semName := "test-semaphore"
+
+	fakeClock := kubesync.NewFakeClock(time.Now().UTC())
+
+	sync1 := kubesync.New(k, namespace, instanceID+"-1")
+	sync1.SetClock(fakeClock)
+	sync2 := kubesync.New(k, namespace, instanceID+"-2")
+	sync2.SetClock(fakeClock)
+
+	sem1 := sync1.NewSemaphore(semName, 1)
+	sem2 := sync2.NewSemaphore(semName, 1)
+
+	assert.NilError(t, sem1.Acquire(t.Context()), "failed to acquire the only slot")
+
+	// Step past expiration without sem1 ever renewing, simulating a
+	// crashed holder.
+	fakeClock.Step(11 * time.Second)
+
+	assert.NilError(t, sem2.Acquire(t.Context()), "failed to acquire the slot after it expired")
+	assert.NilError(t, sem2.Release(t.Context()), "failed to release slot")
+}
+
+func TestSemaphoreReleaseWithoutAcquireFails(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	sync := kubesync.New(k, namespace, instanceID)
+	sem := sync.NewSemaphore("test-semaphore", 1)
+
+	assert.ErrorIs(t, sem.Release(t.Context()), kubesync.ErrNotLocked)
+}
+
+func TestSemaphoreExtend(t *testing.T) {
+	t.Parallel()
+
+	k, namespace, instanceID := getMutexInputs(t)
+	sync := kubesync.New(k, namespace, instanceID)
+	sem := sync.NewSemaphore("test-semaphore", 1)
+
+	assert.NilError(t, sem.Acquire(t.Context()), "failed to acquire slot")
+	assert.NilError(t, sem.Extend(t.Context()), "failed to extend slot")
+	assert.NilError(t, sem.Release(t.Context()), "failed to release slot")
+}
@@ -20,26 +20,50 @@ package kubesync
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclientv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
 )
 
-// sleep implements a content aware sleep function. If the given context
-// is cancelled, this function immediately returns the context error.
-// Otherwise, this function will wait until the duration provided has
-// elapsed and return.
-func sleep(ctx context.Context, d time.Duration) error {
+// sleep implements a content aware sleep function using clk. If the
+// given context is cancelled, this function immediately returns the
+// context error. Otherwise, this function will wait until the duration
+// provided has elapsed and return.
+func sleep(ctx context.Context, clk Clock, d time.Duration) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(d):
+	case <-clk.After(d):
 		return nil
 	}
 }
 
-// now returns the current time in UTC as a metav1.MicroTime object.
-func now() *metav1.MicroTime {
-	m := metav1.NewMicroTime(time.Now().UTC())
+// now returns clk's current time in UTC as a metav1.MicroTime object.
+func now(clk Clock) *metav1.MicroTime {
+	m := metav1.NewMicroTime(clk.Now().UTC())
 	return &m
 }
+
+// ensureLeaseExists creates the lease backing a lock primitive, if it
+// does not already exist. An existing lease is left untouched.
+func ensureLeaseExists(ctx context.Context, lease coordinationclientv1.LeaseInterface, namespace, name string) error {
+	if _, err := lease.Create(ctx, &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"kubesync.jaredallard.github.com/lock": "true",
+			},
+		},
+		Spec: coordinationv1.LeaseSpec{},
+	}, metav1.CreateOptions{}); err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create lease '%s/%s': %w", namespace, name, err)
+		}
+	}
+	return nil
+}